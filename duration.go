@@ -17,8 +17,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // This code was adapted from prometheus/common/model/time.go
@@ -26,79 +31,229 @@ import (
 
 type Duration time.Duration
 
-var durationRE = regexp.MustCompile("^([0-9]+)(y|w|d|h|m|s|ms)$")
+// Units maps each unit recognised by ParseDuration and String to the
+// time.Duration it represents, assuming that a year always has 365d, a week
+// always has 7d, and a day always has 24h. It is exported so callers can
+// register custom units (e.g. Units["mo"] = 30*24*time.Hour) or introspect
+// the supported set.
+//
+// Units is a plain map: it is not safe to mutate concurrently with itself
+// or with any ParseDuration/String/MarshalText/MarshalYAML call, and the
+// regular expression and render order derived from it are cached rather
+// than recomputed on every call. Register custom units during program
+// initialization, before Duration is used concurrently, then call
+// ResetUnitsCache so the new units take effect.
+var Units = map[string]time.Duration{
+	"y":  365 * 24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+	"d":  24 * time.Hour,
+	"h":  time.Hour,
+	"m":  time.Minute,
+	"s":  time.Second,
+	"ms": time.Millisecond,
+	"ns": time.Nanosecond,
+}
+
+// unitsCache holds state derived from Units: the regular expression
+// ParseDuration matches tokens against, the order String renders components
+// in (largest factor first), and the largest registered factor. Building
+// these requires a sort and a regexp compile, so they are computed once and
+// cached rather than redone on every ParseDuration/String call.
+type unitsCache struct {
+	re        *regexp.Regexp
+	order     []string
+	maxFactor time.Duration
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   *unitsCache
+)
+
+// ResetUnitsCache invalidates the regular expression and render order
+// cached from Units. Call it after registering a custom unit in Units and
+// before any concurrent use of Duration; Units itself must not be mutated
+// concurrently with this or with ParseDuration/String.
+func ResetUnitsCache() {
+	cacheMu.Lock()
+	cache = nil
+	cacheMu.Unlock()
+}
+
+func getUnitsCache() *unitsCache {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if cache == nil {
+		cache = buildUnitsCache()
+	}
+	return cache
+}
+
+func buildUnitsCache() *unitsCache {
+	names := make([]string, 0, len(Units))
+	for name := range Units {
+		names = append(names, name)
+	}
+
+	// byLength determines alternation order in the regular expression below,
+	// longest first, so that e.g. "ms" is tried before "m".
+	byLength := append([]string(nil), names...)
+	sort.Slice(byLength, func(i, j int) bool { return len(byLength[i]) > len(byLength[j]) })
+	quoted := make([]string, len(byLength))
+	for i, name := range byLength {
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+
+	// byFactor is the order String renders components in, largest unit
+	// first.
+	byFactor := append([]string(nil), names...)
+	sort.Slice(byFactor, func(i, j int) bool { return Units[byFactor[i]] > Units[byFactor[j]] })
+
+	var maxFactor time.Duration
+	for _, factor := range Units {
+		if factor > maxFactor {
+			maxFactor = factor
+		}
+	}
+
+	return &unitsCache{
+		// re matches a single "[-]<int><unit>" token. ParseDuration walks a
+		// string matching this repeatedly to support compound durations
+		// such as "1h30m"; the leading sign, if present, is only honoured
+		// on the first token and applies to the duration as a whole.
+		re:        regexp.MustCompile("^(-)?([0-9]+)(" + strings.Join(quoted, "|") + ")"),
+		order:     byFactor,
+		maxFactor: maxFactor,
+	}
+}
 
-// ParseDuration parses a string into a time.Duration, assuming that a year
-// always has 365d, a week always has 7d, and a day always has 24h.
+// ParseDuration parses a string into a Duration. It accepts a single
+// "<int><unit>" token (e.g. "5s") as well as compound expressions formed by
+// concatenating tokens in descending unit order (e.g. "1h30m", "2d12h"), an
+// optional leading "-" for negative durations, and the unitless string "0"
+// as zero. Any other unitless number is rejected with a "missing unit"
+// error, mirroring time.ParseDuration.
 func ParseDuration(durationStr string) (Duration, error) {
-	matches := durationRE.FindStringSubmatch(durationStr)
-	if len(matches) != 3 {
-		return 0, fmt.Errorf("not a valid duration string: %q", durationStr)
+	if durationStr == "0" || durationStr == "-0" {
+		return 0, nil
 	}
+
+	c := getUnitsCache()
 	var (
-		n, _ = strconv.Atoi(matches[1])
-		dur  = time.Duration(n) * time.Millisecond
+		total      time.Duration
+		remaining  = durationStr
+		lastFactor = c.maxFactor + 1 // larger than any real factor
+		neg        bool
+		first      = true
 	)
-	switch unit := matches[2]; unit {
-	case "y":
-		dur *= 1000 * 60 * 60 * 24 * 365
-	case "w":
-		dur *= 1000 * 60 * 60 * 24 * 7
-	case "d":
-		dur *= 1000 * 60 * 60 * 24
-	case "h":
-		dur *= 1000 * 60 * 60
-	case "m":
-		dur *= 1000 * 60
-	case "s":
-		dur *= 1000
-	case "ms":
-		// Value already correct
-	default:
-		return 0, fmt.Errorf("invalid time unit in duration string: %q", unit)
-	}
-	return Duration(dur), nil
+	for remaining != "" {
+		matches := c.re.FindStringSubmatch(remaining)
+		if matches == nil {
+			break
+		}
+		if first {
+			neg = matches[1] == "-"
+			first = false
+		} else if matches[1] == "-" {
+			break
+		}
+		n, _ := strconv.Atoi(matches[2])
+		factor := Units[matches[3]]
+		if factor >= lastFactor {
+			return 0, fmt.Errorf("invalid duration string (units out of order or repeated): %q", durationStr)
+		}
+		lastFactor = factor
+		total += time.Duration(n) * factor
+		remaining = remaining[len(matches[0]):]
+	}
+	if remaining != "" || lastFactor > c.maxFactor {
+		// remaining holds whatever is left unconsumed, which is the whole
+		// string if no token matched at all, or a trailing fragment (e.g.
+		// the "30" in "5h30") otherwise; strconv.Atoi handles a leading "-".
+		if _, err := strconv.Atoi(remaining); err == nil {
+			return 0, fmt.Errorf("missing unit in duration %q", durationStr)
+		}
+		return 0, fmt.Errorf("not a valid duration string: %q", durationStr)
+	}
+	if neg {
+		total = -total
+	}
+	return Duration(total), nil
 }
 
+// MustParseDuration is like ParseDuration but panics if durationStr cannot
+// be parsed. It is intended for use in variable initializers.
+func MustParseDuration(durationStr string) Duration {
+	d, err := ParseDuration(durationStr)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// String renders d as a compound expression, taking as many of the largest
+// unit as fit and continuing with the remainder in the next smaller unit, so
+// 90m renders as "1h30m" and 14d as "2w"; units with a zero count are
+// omitted. Since "ns" is always among Units, this never produces an empty or
+// unit-less result, even for sub-millisecond durations (e.g. "500ns").
 func (d Duration) String() string {
-	var (
-		ms   = int64(time.Duration(d) / time.Millisecond)
-		unit = "ms"
-	)
-	factors := map[string]int64{
-		"y":  1000 * 60 * 60 * 24 * 365,
-		"w":  1000 * 60 * 60 * 24 * 7,
-		"d":  1000 * 60 * 60 * 24,
-		"h":  1000 * 60 * 60,
-		"m":  1000 * 60,
-		"s":  1000,
-		"ms": 1,
-	}
-
-	switch int64(0) {
-	case ms % factors["y"]:
-		unit = "y"
-	case ms % factors["w"]:
-		unit = "w"
-	case ms % factors["d"]:
-		unit = "d"
-	case ms % factors["h"]:
-		unit = "h"
-	case ms % factors["m"]:
-		unit = "m"
-	case ms % factors["s"]:
-		unit = "s"
-	}
-	return fmt.Sprintf("%v%v", ms/factors[unit], unit)
-}
-
-// MarshalJSON implements the json.Marshaler interface.
+	t := time.Duration(d)
+	if t == 0 {
+		return "0s"
+	}
+	sign := ""
+	if t < 0 {
+		sign, t = "-", -t
+	}
+
+	var b strings.Builder
+	for _, unit := range getUnitsCache().order {
+		factor := Units[unit]
+		if t < factor {
+			continue
+		}
+		n := t / factor
+		t -= n * factor
+		fmt.Fprintf(&b, "%d%s", n, unit)
+	}
+	return sign + b.String()
+}
+
+// Validate returns an error if d is outside the inclusive range [min, max].
+func (d Duration) Validate(min, max Duration) error {
+	if d < min {
+		return fmt.Errorf("duration %s is less than minimum %s", d, min)
+	}
+	if d > max {
+		return fmt.Errorf("duration %s is greater than maximum %s", d, max)
+	}
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface. A duration that is an
+// exact multiple of a millisecond round-trips as its human-readable string
+// form; any other duration (one with a sub-millisecond remainder) is
+// encoded as a raw nanosecond number instead.
 func (d Duration) MarshalJSON() ([]byte, error) {
+	if time.Duration(d)%time.Millisecond != 0 {
+		return []byte(strconv.FormatInt(int64(d), 10)), nil
+	}
 	return []byte(`"` + d.String() + `"`), nil
 }
 
-// UnmarshalJSON implements the yaml.Unmarshaler interface.
+// UnmarshalJSON implements the json.Unmarshaler interface. A bare JSON
+// number is interpreted as nanoseconds, matching time.Duration; a string is
+// parsed with ParseDuration.
 func (d *Duration) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] != '"' {
+		var n int64
+		if err := json.Unmarshal(data, &n); err != nil {
+			return err
+		}
+		*d = Duration(n)
+		return nil
+	}
+
 	var s string
 	if err := json.Unmarshal(data, &s); err != nil {
 		return err
@@ -110,3 +265,55 @@ func (d *Duration) UnmarshalJSON(data []byte) error {
 	*d = dur
 	return nil
 }
+
+// Set implements the flag.Value and pflag.Value interfaces, so a Duration
+// can be bound directly with flag.Var or a Cobra/Viper flag.
+func (d *Duration) Set(s string) error {
+	dur, err := ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = dur
+	return nil
+}
+
+// Type implements the pflag.Value interface.
+func (d Duration) Type() string {
+	return "duration"
+}
+
+// MarshalYAML implements the yaml.Marshaler interface. The signature is
+// shared by gopkg.in/yaml.v2 and gopkg.in/yaml.v3.
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// UnmarshalYAML implements the gopkg.in/yaml.v3 node-based yaml.Unmarshaler
+// interface. (yaml.v2's unmarshaler has an incompatible method signature and
+// can't be implemented alongside it on the same type; MarshalText/
+// UnmarshalText cover yaml.v2 and other text-based decoders instead.)
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	dur, err := ParseDuration(value.Value)
+	if err != nil {
+		return err
+	}
+	*d = dur
+	return nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface, used by
+// BurntSushi/toml and env encoders. (yaml.v2 and yaml.v3 use MarshalYAML.)
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface, used by
+// BurntSushi/toml, env decoders, and gopkg.in/yaml.v2.
+func (d *Duration) UnmarshalText(text []byte) error {
+	dur, err := ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = dur
+	return nil
+}
@@ -0,0 +1,233 @@
+package jsonutil_test
+
+import (
+	"encoding/json"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/fresh8/jsonutil"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDurationFlagValue(t *testing.T) {
+	var d jsonutil.Duration
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&d, "interval", "")
+
+	if err := fs.Parse([]string{"-interval=5m"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if want := jsonutil.Duration(5 * 60 * 1e9); d != want {
+		t.Errorf("d = %v, want %v", d, want)
+	}
+}
+
+func TestDurationSetType(t *testing.T) {
+	var d jsonutil.Duration
+	if err := d.Set("1h"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, want := d.String(), "1h"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	if err := d.Set("not-a-duration"); err == nil {
+		t.Error("Set() with invalid input: want error, got nil")
+	}
+
+	if got, want := d.Type(), "duration"; got != want {
+		t.Errorf("Type() = %q, want %q", got, want)
+	}
+
+	var _ flag.Value = &d
+}
+
+func TestParseDurationCompound(t *testing.T) {
+	cases := map[string]time.Duration{
+		"0":     0,
+		"1h30m": time.Hour + 30*time.Minute,
+		"2d12h": 2*24*time.Hour + 12*time.Hour,
+		"90s":   90 * time.Second,
+		"500ms": 500 * time.Millisecond,
+	}
+	for in, want := range cases {
+		got, err := jsonutil.ParseDuration(in)
+		if err != nil {
+			t.Errorf("ParseDuration(%q) error = %v", in, err)
+			continue
+		}
+		if time.Duration(got) != want {
+			t.Errorf("ParseDuration(%q) = %v, want %v", in, time.Duration(got), want)
+		}
+	}
+}
+
+func TestParseDurationErrors(t *testing.T) {
+	cases := []string{"100", "5h30", "30m1h", "1h1h", "abc"}
+	for _, in := range cases {
+		if _, err := jsonutil.ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%q): want error, got nil", in)
+		}
+	}
+}
+
+func TestParseDurationMissingUnitMessage(t *testing.T) {
+	_, err := jsonutil.ParseDuration("5h30")
+	if err == nil {
+		t.Fatal("ParseDuration(\"5h30\"): want error, got nil")
+	}
+	if got, want := err.Error(), `missing unit in duration "5h30"`; got != want {
+		t.Errorf("err = %q, want %q", got, want)
+	}
+}
+
+func TestDurationUnmarshalJSONNumeric(t *testing.T) {
+	var d jsonutil.Duration
+	if err := json.Unmarshal([]byte("123456789"), &d); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if time.Duration(d) != 123456789*time.Nanosecond {
+		t.Errorf("d = %v, want 123456789ns", time.Duration(d))
+	}
+}
+
+func TestDurationJSONRoundTripSubMillisecond(t *testing.T) {
+	d := jsonutil.Duration(123456789 * time.Nanosecond)
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var got jsonutil.Duration
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got != d {
+		t.Errorf("round-trip = %v, want %v", time.Duration(got), time.Duration(d))
+	}
+}
+
+func TestDurationStringSubMillisecond(t *testing.T) {
+	if got, want := jsonutil.Duration(500*time.Nanosecond).String(), "500ns"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got, want := jsonutil.Duration(-500*time.Nanosecond).String(), "-500ns"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	min, max := jsonutil.Duration(time.Second), jsonutil.Duration(time.Minute)
+	err := jsonutil.Duration(500 * time.Nanosecond).Validate(min, max)
+	if err == nil {
+		t.Fatal("Validate() below minimum: want error, got nil")
+	}
+	if want := `duration 500ns is less than minimum 1s`; err.Error() != want {
+		t.Errorf("Validate() error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestDurationYAMLRoundTrip(t *testing.T) {
+	d := jsonutil.Duration(90 * time.Minute)
+	data, err := yaml.Marshal(d)
+	if err != nil {
+		t.Fatalf("yaml.Marshal() error = %v", err)
+	}
+	if want := "1h30m\n"; string(data) != want {
+		t.Errorf("yaml.Marshal() = %q, want %q", data, want)
+	}
+
+	var got jsonutil.Duration
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+	if got != d {
+		t.Errorf("round-trip = %v, want %v", time.Duration(got), time.Duration(d))
+	}
+}
+
+func TestDurationTextRoundTrip(t *testing.T) {
+	d := jsonutil.Duration(2*24*time.Hour + 12*time.Hour)
+	text, err := d.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText() error = %v", err)
+	}
+	if want := "2d12h"; string(text) != want {
+		t.Errorf("MarshalText() = %q, want %q", text, want)
+	}
+
+	var got jsonutil.Duration
+	if err := got.UnmarshalText(text); err != nil {
+		t.Fatalf("UnmarshalText() error = %v", err)
+	}
+	if got != d {
+		t.Errorf("round-trip = %v, want %v", time.Duration(got), time.Duration(d))
+	}
+}
+
+func TestParseDurationNegative(t *testing.T) {
+	d, err := jsonutil.ParseDuration("-5m")
+	if err != nil {
+		t.Fatalf("ParseDuration() error = %v", err)
+	}
+	if want := jsonutil.Duration(-5 * time.Minute); d != want {
+		t.Errorf("ParseDuration() = %v, want %v", d, want)
+	}
+	if got, want := d.String(), "-5m"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	zero, err := jsonutil.ParseDuration("-0")
+	if err != nil {
+		t.Fatalf("ParseDuration(\"-0\") error = %v", err)
+	}
+	if zero != 0 {
+		t.Errorf("ParseDuration(\"-0\") = %v, want 0", zero)
+	}
+}
+
+func TestMustParseDuration(t *testing.T) {
+	if got, want := jsonutil.MustParseDuration("1h"), jsonutil.Duration(time.Hour); got != want {
+		t.Errorf("MustParseDuration() = %v, want %v", got, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustParseDuration() with invalid input: want panic, got none")
+		}
+	}()
+	jsonutil.MustParseDuration("not-a-duration")
+}
+
+func TestDurationValidate(t *testing.T) {
+	min, max := jsonutil.Duration(time.Second), jsonutil.Duration(time.Minute)
+
+	if err := jsonutil.Duration(30 * time.Second).Validate(min, max); err != nil {
+		t.Errorf("Validate() in range: error = %v", err)
+	}
+	if err := jsonutil.Duration(time.Millisecond).Validate(min, max); err == nil {
+		t.Error("Validate() below minimum: want error, got nil")
+	}
+	if err := jsonutil.Duration(time.Hour).Validate(min, max); err == nil {
+		t.Error("Validate() above maximum: want error, got nil")
+	}
+}
+
+func TestUnitsCustomRegistration(t *testing.T) {
+	jsonutil.Units["mo"] = 30 * 24 * time.Hour
+	jsonutil.ResetUnitsCache()
+	defer func() {
+		delete(jsonutil.Units, "mo")
+		jsonutil.ResetUnitsCache()
+	}()
+
+	d, err := jsonutil.ParseDuration("2mo")
+	if err != nil {
+		t.Fatalf("ParseDuration(\"2mo\") error = %v", err)
+	}
+	if want := jsonutil.Duration(2 * 30 * 24 * time.Hour); d != want {
+		t.Errorf("ParseDuration(\"2mo\") = %v, want %v", time.Duration(d), time.Duration(want))
+	}
+	if got, want := d.String(), "2mo"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2013 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Timestamp is a time.Time that mirrors the design of Duration: it marshals
+// to/from JSON as either an RFC3339 string or a Unix milliseconds number.
+// This fills the Timestamp half of the upstream prometheus/common/model
+// package that Duration was adapted from, which also provides a Time type
+// alongside its Duration.
+type Timestamp time.Time
+
+// TimestampFormat selects the JSON encoding that Timestamp.MarshalJSON
+// produces.
+type TimestampFormat int
+
+const (
+	// TimestampRFC3339 encodes a Timestamp as an RFC3339 string.
+	TimestampRFC3339 TimestampFormat = iota
+	// TimestampUnixMilli encodes a Timestamp as a JSON number of Unix
+	// milliseconds.
+	TimestampUnixMilli
+)
+
+// DefaultTimestampFormat controls how Timestamp.MarshalJSON encodes values.
+// UnmarshalJSON and ParseTimestamp always accept either encoding regardless
+// of this setting.
+//
+// DefaultTimestampFormat is a plain package-level variable shared by every
+// Timestamp in the process: it is not safe to mutate concurrently with
+// itself or with any MarshalJSON call. Set it once during program
+// initialization, before any Timestamp is marshalled concurrently, and
+// never change it afterwards.
+var DefaultTimestampFormat = TimestampRFC3339
+
+// ParseTimestamp parses a string into a Timestamp. It accepts an RFC3339
+// timestamp or a bare integer number of Unix milliseconds.
+func ParseTimestamp(s string) (Timestamp, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return Timestamp(time.UnixMilli(ms)), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return Timestamp{}, fmt.Errorf("not a valid timestamp string: %q", s)
+	}
+	return Timestamp(t), nil
+}
+
+func (t Timestamp) String() string {
+	return time.Time(t).Format(time.RFC3339)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if DefaultTimestampFormat == TimestampUnixMilli {
+		return []byte(strconv.FormatInt(time.Time(t).UnixMilli(), 10)), nil
+	}
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. It accepts
+// either an RFC3339 string or a JSON number of Unix milliseconds.
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] != '"' {
+		var ms int64
+		if err := json.Unmarshal(data, &ms); err != nil {
+			return err
+		}
+		*t = Timestamp(time.UnixMilli(ms))
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	ts, err := ParseTimestamp(s)
+	if err != nil {
+		return err
+	}
+	*t = ts
+	return nil
+}
+
+// Set implements the flag.Value and pflag.Value interfaces, so a Timestamp
+// can be bound directly with flag.Var or a Cobra/Viper flag.
+func (t *Timestamp) Set(s string) error {
+	ts, err := ParseTimestamp(s)
+	if err != nil {
+		return err
+	}
+	*t = ts
+	return nil
+}
+
+// Type implements the pflag.Value interface.
+func (t Timestamp) Type() string {
+	return "timestamp"
+}
@@ -0,0 +1,84 @@
+package jsonutil_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/fresh8/jsonutil"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	got, err := jsonutil.ParseTimestamp("2026-07-26T09:00:00Z")
+	if err != nil {
+		t.Fatalf("ParseTimestamp() error = %v", err)
+	}
+	want := time.Date(2026, 7, 26, 9, 0, 0, 0, time.UTC)
+	if !time.Time(got).Equal(want) {
+		t.Errorf("ParseTimestamp() = %v, want %v", time.Time(got), want)
+	}
+
+	gotMs, err := jsonutil.ParseTimestamp("1700000000000")
+	if err != nil {
+		t.Fatalf("ParseTimestamp() error = %v", err)
+	}
+	if !time.Time(gotMs).Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("ParseTimestamp() = %v, want %v", time.Time(gotMs), time.UnixMilli(1700000000000))
+	}
+
+	if _, err := jsonutil.ParseTimestamp("not-a-timestamp"); err == nil {
+		t.Error("ParseTimestamp() with invalid input: want error, got nil")
+	}
+}
+
+func TestTimestampJSONRFC3339(t *testing.T) {
+	ts := jsonutil.Timestamp(time.Date(2026, 7, 26, 9, 0, 0, 0, time.UTC))
+	data, err := json.Marshal(ts)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := `"2026-07-26T09:00:00Z"`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var got jsonutil.Timestamp
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !time.Time(got).Equal(time.Time(ts)) {
+		t.Errorf("round-trip = %v, want %v", time.Time(got), time.Time(ts))
+	}
+}
+
+func TestTimestampJSONUnixMilli(t *testing.T) {
+	prev := jsonutil.DefaultTimestampFormat
+	jsonutil.DefaultTimestampFormat = jsonutil.TimestampUnixMilli
+	defer func() { jsonutil.DefaultTimestampFormat = prev }()
+
+	ts := jsonutil.Timestamp(time.UnixMilli(1700000000000))
+	data, err := json.Marshal(ts)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := "1700000000000"; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var got jsonutil.Timestamp
+	if err := json.Unmarshal([]byte("1700000000000"), &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !time.Time(got).Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("Unmarshal() = %v, want %v", time.Time(got), time.UnixMilli(1700000000000))
+	}
+}
+
+func TestTimestampSetType(t *testing.T) {
+	var ts jsonutil.Timestamp
+	if err := ts.Set("2026-07-26T09:00:00Z"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, want := ts.Type(), "timestamp"; got != want {
+		t.Errorf("Type() = %q, want %q", got, want)
+	}
+}